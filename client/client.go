@@ -44,7 +44,13 @@ func init() {
 
 // NewForConfig creates a resilient client-go that, in case of connection failures,
 // tries to connect to all the available apiservers in the cluster.
-func NewForConfig(ctx context.Context, config *rest.Config) (*kubernetes.Clientset, error) {
+//
+// The returned *multidialer.Dialer is a handle for the resolver goroutine
+// started behind the scenes: call Close on it during shutdown to stop the
+// resolver and force-close any connections still pinned to apiserver
+// backends that have since been removed, and RegisterMetrics on it to
+// expose the dialer's Prometheus metrics.
+func NewForConfig(ctx context.Context, config *rest.Config) (*kubernetes.Clientset, *multidialer.Dialer, error) {
 	if loadBalancerBypass {
 		return loadBalancerBypassClient(ctx, config)
 	}
@@ -53,45 +59,55 @@ func NewForConfig(ctx context.Context, config *rest.Config) (*kubernetes.Clients
 
 // failoverClient creates a resilient client-go that, in case of connection failures,
 // tries to connect to all the available apiservers in the cluster.
-func failoverClient(ctx context.Context, config *rest.Config) (*kubernetes.Clientset, error) {
+func failoverClient(ctx context.Context, config *rest.Config) (*kubernetes.Clientset, *multidialer.Dialer, error) {
 	// create the clientset
 	configShallowCopy := *config
 	// it wraps the custom dialer if exists
 	d := multidialer.NewDialer(configShallowCopy.Dial)
-	// use the multidialier for our clientset
-	configShallowCopy.Dial = d.DialContext
+	// route the multidialer through transport.DialHolder instead of
+	// configShallowCopy.Dial; every call creates a new Dialer, and with it
+	// a new DialHolder, so clientsets from separate NewForConfig calls do
+	// not share a cached *http.Transport
+	if err := d.WrapTransport(&configShallowCopy); err != nil {
+		return nil, d, err
+	}
 	// create the clientset with our own dialer
 	cs, err := kubernetes.NewForConfig(&configShallowCopy)
 	if err != nil {
-		return cs, err
+		return cs, d, err
 	}
 	// start the resolver to update the list of available apiservers
 	// !!! using our own dialer !!!
 	d.Start(ctx, cs)
-	return cs, nil
+	return cs, d, nil
 }
 
 // loadBalancerBypassClient creates a client-go that, always connects to the apiserver backends directly first,
 // in case of connection failures, it will fall back to the original load balancer address.
-func loadBalancerBypassClient(ctx context.Context, config *rest.Config) (*kubernetes.Clientset, error) {
+func loadBalancerBypassClient(ctx context.Context, config *rest.Config) (*kubernetes.Clientset, *multidialer.Dialer, error) {
 	var retCs, ownCs *kubernetes.Clientset
 	var err error
 	configShallowCopy := *config
 	// create the clientset for our own dialer
 	ownCs, err = kubernetes.NewForConfig(&configShallowCopy)
 	if err != nil {
-		return ownCs, err
+		return ownCs, nil, err
 	}
 	// it wraps the custom dialer if exists
 	d := multidialer.NewDialer(configShallowCopy.Dial)
 	// start the resolver to update the list of available apiservers
 	d.Start(ctx, ownCs)
 
-	// use the multidialier for to-be-returned clientset
-	configShallowCopy.Dial = d.DialContext
+	// route the multidialer through transport.DialHolder instead of
+	// configShallowCopy.Dial; every call creates a new Dialer, and with it
+	// a new DialHolder, so clientsets from separate NewForConfig calls do
+	// not share a cached *http.Transport
+	if err := d.WrapTransport(&configShallowCopy); err != nil {
+		return nil, d, err
+	}
 	retCs, err = kubernetes.NewForConfig(&configShallowCopy)
 	if err != nil {
-		return retCs, err
+		return retCs, d, err
 	}
-	return retCs, nil
+	return retCs, d, nil
 }
@@ -0,0 +1,21 @@
+package client
+
+import (
+	"context"
+
+	"github.com/aojea/client-go-multidialer/multidialer"
+
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+// NewDiscoveryForConfig creates a resilient discovery client the same way
+// NewForConfig creates a resilient *kubernetes.Clientset.
+func NewDiscoveryForConfig(ctx context.Context, config *rest.Config) (*discovery.DiscoveryClient, *multidialer.Dialer, error) {
+	wrapped, d, err := wrapConfig(ctx, config)
+	if err != nil {
+		return nil, d, err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(wrapped)
+	return dc, d, err
+}
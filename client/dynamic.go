@@ -0,0 +1,21 @@
+package client
+
+import (
+	"context"
+
+	"github.com/aojea/client-go-multidialer/multidialer"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// NewDynamicForConfig creates a resilient dynamic client the same way
+// NewForConfig creates a resilient *kubernetes.Clientset.
+func NewDynamicForConfig(ctx context.Context, config *rest.Config) (dynamic.Interface, *multidialer.Dialer, error) {
+	wrapped, d, err := wrapConfig(ctx, config)
+	if err != nil {
+		return nil, d, err
+	}
+	dc, err := dynamic.NewForConfig(wrapped)
+	return dc, d, err
+}
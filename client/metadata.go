@@ -0,0 +1,21 @@
+package client
+
+import (
+	"context"
+
+	"github.com/aojea/client-go-multidialer/multidialer"
+
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+)
+
+// NewMetadataForConfig creates a resilient metadata client the same way
+// NewForConfig creates a resilient *kubernetes.Clientset.
+func NewMetadataForConfig(ctx context.Context, config *rest.Config) (metadata.Interface, *multidialer.Dialer, error) {
+	wrapped, d, err := wrapConfig(ctx, config)
+	if err != nil {
+		return nil, d, err
+	}
+	mc, err := metadata.NewForConfig(wrapped)
+	return mc, d, err
+}
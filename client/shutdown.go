@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aojea/client-go-multidialer/multidialer"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// defaultGracePeriod is how long NewForConfigWithShutdown lets in-flight
+// requests finish against already-dialed backends before force-closing
+// every connection.
+const defaultGracePeriod = 30 * time.Second
+
+type shutdownOptions struct {
+	stop  <-chan struct{}
+	grace time.Duration
+}
+
+// ShutdownOption configures NewForConfigWithShutdown.
+type ShutdownOption func(*shutdownOptions)
+
+// WithStopChannel makes NewForConfigWithShutdown drain when stop closes,
+// instead of installing its own SIGINT/SIGTERM handler.
+func WithStopChannel(stop <-chan struct{}) ShutdownOption {
+	return func(o *shutdownOptions) { o.stop = stop }
+}
+
+// WithGracePeriod overrides how long in-flight connections get to finish
+// once a shutdown is observed, before they're force-closed. The default
+// is 30 seconds.
+func WithGracePeriod(grace time.Duration) ShutdownOption {
+	return func(o *shutdownOptions) { o.grace = grace }
+}
+
+// NewForConfigWithShutdown is NewForConfig plus graceful-shutdown
+// handling: once a SIGINT/SIGTERM is received (or the stop channel passed
+// via WithStopChannel closes), the Dialer backing the returned clientset
+// stops opening new connections to any backend, lets connections already
+// open finish for the configured grace period, then force-closes
+// everything and stops the endpoints resolver. This makes the library
+// safe to embed in long-running controllers that need to terminate
+// cleanly without stranding in-flight requests on an apiserver IP that's
+// about to disappear.
+func NewForConfigWithShutdown(ctx context.Context, config *rest.Config, opts ...ShutdownOption) (*kubernetes.Clientset, *multidialer.Dialer, error) {
+	o := shutdownOptions{grace: defaultGracePeriod}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cs, d, err := NewForConfig(ctx, config)
+	if err != nil {
+		return cs, d, err
+	}
+
+	stop := o.stop
+	if stop == nil {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		internalStop := make(chan struct{})
+		go func() {
+			defer signal.Stop(sigCh)
+			select {
+			case <-sigCh:
+				close(internalStop)
+			case <-ctx.Done():
+			}
+		}()
+		stop = internalStop
+	}
+
+	go func() {
+		select {
+		case <-stop:
+			d.Drain(o.grace)
+		case <-ctx.Done():
+		}
+	}()
+
+	return cs, d, nil
+}
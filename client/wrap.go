@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+
+	"github.com/aojea/client-go-multidialer/multidialer"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// wrapConfig builds a minimal bootstrap clientset from config, starts the
+// endpoints resolver against it, and returns a shallow copy of config with
+// the resulting Dialer's transport installed via WrapTransport. It honors
+// the same Failover / LoadBalancer-Bypass mode selection as NewForConfig.
+func wrapConfig(ctx context.Context, config *rest.Config) (*rest.Config, *multidialer.Dialer, error) {
+	configShallowCopy := *config
+
+	if !loadBalancerBypass {
+		// Failover mode: the resolver watches through the same clientset
+		// that ends up using the multidialer, same as failoverClient.
+		d := multidialer.NewDialer(configShallowCopy.Dial)
+		if err := d.WrapTransport(&configShallowCopy); err != nil {
+			return nil, d, err
+		}
+		cs, err := kubernetes.NewForConfig(&configShallowCopy)
+		if err != nil {
+			return nil, d, err
+		}
+		d.Start(ctx, cs)
+		return &configShallowCopy, d, nil
+	}
+
+	// LoadBalancer-Bypass mode: resolve through a plain clientset first,
+	// same as loadBalancerBypassClient, then hand back a config wired to
+	// the multidialer.
+	ownCs, err := kubernetes.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, nil, err
+	}
+	d := multidialer.NewDialer(configShallowCopy.Dial)
+	d.Start(ctx, ownCs)
+	if err := d.WrapTransport(&configShallowCopy); err != nil {
+		return nil, d, err
+	}
+	return &configShallowCopy, d, nil
+}
+
+// WrapConfig returns a shallow copy of config with the multidialer's
+// transport already installed and its endpoints resolver already running
+// against a minimal bootstrap clientset.
+//
+// Pass the result to any client-go constructor that accepts a
+// *rest.Config -- a plain RESTClient, dynamic.NewForConfig,
+// discovery.NewDiscoveryClientForConfig, controller-runtime's
+// manager.New, aggregated-apiserver code, and so on -- to give it the
+// same Failover / LoadBalancer-Bypass behavior as NewForConfig, even
+// though this package only ships typed constructors for a handful of
+// client kinds.
+func WrapConfig(ctx context.Context, config *rest.Config) (*rest.Config, error) {
+	wrapped, _, err := wrapConfig(ctx, config)
+	return wrapped, err
+}
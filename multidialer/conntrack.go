@@ -0,0 +1,63 @@
+package multidialer
+
+import "net"
+
+// trackedConn is a net.Conn that de-registers itself from its Dialer when
+// closed, so the Dialer's bookkeeping of open connections per backend
+// never outlives the connections it describes.
+type trackedConn struct {
+	net.Conn
+	d       *Dialer
+	backend string
+}
+
+func (c *trackedConn) Close() error {
+	c.d.untrack(c.backend, c)
+	c.d.notifyConnClosed(c.backend)
+	return c.Conn.Close()
+}
+
+// track wraps conn so the Dialer can force-close it later if backend is
+// dropped from the discovered backend set. Unlike CloseIdleConnectionsFor
+// in k8s.io/apimachinery/pkg/util/net, which closes the idle connections
+// of a whole transport, this closes every connection to one address,
+// idle or not.
+func (d *Dialer) track(backend string, conn net.Conn) net.Conn {
+	tc := &trackedConn{Conn: conn, d: d, backend: backend}
+
+	d.connsMu.Lock()
+	if d.conns == nil {
+		d.conns = make(map[string]map[*trackedConn]struct{})
+	}
+	if d.conns[backend] == nil {
+		d.conns[backend] = make(map[*trackedConn]struct{})
+	}
+	d.conns[backend][tc] = struct{}{}
+	d.connsMu.Unlock()
+
+	return tc
+}
+
+func (d *Dialer) untrack(backend string, tc *trackedConn) {
+	d.connsMu.Lock()
+	if set, ok := d.conns[backend]; ok {
+		delete(set, tc)
+		if len(set) == 0 {
+			delete(d.conns, backend)
+		}
+	}
+	d.connsMu.Unlock()
+}
+
+// closeConnectionsTo force-closes every connection currently tracked
+// against backend.
+func (d *Dialer) closeConnectionsTo(backend string) {
+	d.connsMu.Lock()
+	set := d.conns[backend]
+	delete(d.conns, backend)
+	d.connsMu.Unlock()
+
+	for tc := range set {
+		tc.Conn.Close()
+	}
+}
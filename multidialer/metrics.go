@@ -0,0 +1,107 @@
+package multidialer
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics holds the Prometheus collectors for a single Dialer. Each Dialer
+// owns its own metrics, and registering them is optional. The collectors
+// carry no per-Dialer label, so registering a second Dialer's metrics with
+// the same Registerer fails as a duplicate registration.
+type metrics struct {
+	dialAttempts         *prometheus.CounterVec
+	dialDuration         *prometheus.HistogramVec
+	failoverFallthroughs prometheus.Counter
+	bypassFallbacks      prometheus.Counter
+	backendsKnown        prometheus.Gauge
+	resolverErrors       prometheus.Counter
+	resolverEmptyGuarded prometheus.Counter
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		dialAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "multidialer",
+			Name:      "dial_attempts_total",
+			Help:      "Number of dial attempts per backend, labeled by outcome (success or failure).",
+		}, []string{"backend", "outcome"}),
+		dialDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "multidialer",
+			Name:      "dial_duration_seconds",
+			Help:      "Duration of dial attempts per backend.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend"}),
+		failoverFallthroughs: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "multidialer",
+			Name:      "failover_fallthroughs_total",
+			Help:      "Number of dials that fell through from the original address to a discovered backend.",
+		}),
+		bypassFallbacks: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "multidialer",
+			Name:      "bypass_fallbacks_total",
+			Help:      "Number of dials that fell back from discovered backends to the original address in LoadBalancer-Bypass mode.",
+		}),
+		backendsKnown: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "multidialer",
+			Name:      "backends_known",
+			Help:      "Current number of apiserver backends known to the endpoints resolver.",
+		}),
+		resolverErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "multidialer",
+			Name:      "resolver_errors_total",
+			Help:      "Number of endpoints resolver refreshes that failed outright (e.g. a transient apiserver error).",
+		}),
+		resolverEmptyGuarded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "multidialer",
+			Name:      "resolver_empty_guarded_total",
+			Help:      "Number of zero-endpoint resolver refreshes suppressed pending enough consecutive confirmations.",
+		}),
+	}
+}
+
+func (m *metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.dialAttempts,
+		m.dialDuration,
+		m.failoverFallthroughs,
+		m.bypassFallbacks,
+		m.backendsKnown,
+		m.resolverErrors,
+		m.resolverEmptyGuarded,
+	}
+}
+
+// RegisterMetrics registers the Dialer's Prometheus metrics with reg. It
+// must be called at most once per Dialer.
+func (d *Dialer) RegisterMetrics(reg prometheus.Registerer) error {
+	for _, c := range d.metrics.collectors() {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dialOne performs a single timed dial attempt against candidate and
+// records it in the dial_attempts_total and dial_duration_seconds metrics.
+func (d *Dialer) dialOne(ctx context.Context, network, candidate string) (net.Conn, error) {
+	d.notifyDialStarted(candidate)
+	start := time.Now()
+	conn, err := d.dial(ctx, network, candidate)
+	elapsed := time.Since(start)
+	d.notifyDialFinished(candidate, elapsed, err)
+	d.metrics.dialDuration.WithLabelValues(candidate).Observe(elapsed.Seconds())
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	d.metrics.dialAttempts.WithLabelValues(candidate, outcome).Inc()
+	if err != nil {
+		return nil, err
+	}
+	return d.track(candidate, conn), nil
+}
@@ -0,0 +1,197 @@
+// Package multidialer implements a net.Dial-compatible dialer that knows
+// how to reach every apiserver behind a Kubernetes "kubernetes" Service,
+// not just the load balancer address configured in the client's
+// *rest.Config. It discovers the backends by watching the Service's
+// Endpoints and dials them directly as a failover or bypass path around
+// the load balancer.
+package multidialer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/client-go/transport"
+)
+
+// envLoadBalancerBypass mirrors client.EnvLoadBalancerBypass. It is
+// duplicated here, rather than imported, because the client package
+// already imports multidialer.
+const envLoadBalancerBypass = "MULTI_DIALER_LOAD_BALANCER_BYPASS"
+
+// DialFunc matches the signature of (*net.Dialer).DialContext and of
+// rest.Config.Dial, so a Dialer can wrap either.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// Dialer dials the address handed to it by the HTTP transport and, on
+// failure, retries against the apiserver backends discovered from the
+// "kubernetes" Endpoints object. In LoadBalancer-Bypass mode the backends
+// are tried first and the original address becomes the fallback.
+type Dialer struct {
+	dial    DialFunc
+	bypass  bool
+	metrics *metrics
+	holder  *transport.DialHolder
+	policy  Policy
+
+	mu               sync.RWMutex
+	backends         []string // host:port of discovered apiserver backends
+	cancel           context.CancelFunc
+	minConfirmations int
+	emptyStreak      int
+
+	connsMu sync.Mutex
+	conns   map[string]map[*trackedConn]struct{}
+
+	draining int32 // set with atomic; see Drain
+}
+
+// NewDialer creates a Dialer that uses dial to reach individual
+// candidates. If dial is nil, a plain *net.Dialer is used. Whether the
+// original address or the discovered backends are tried first is decided
+// by the MULTI_DIALER_LOAD_BALANCER_BYPASS environment variable, mirroring
+// the mode selection in the client package. By default backends are tried
+// in a random order on every dial; pass WithPolicy to change that.
+func NewDialer(dial DialFunc, opts ...Option) *Dialer {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	bypass, _ := strconv.ParseBool(os.Getenv(envLoadBalancerBypass))
+	d := &Dialer{
+		dial:             dial,
+		bypass:           bypass,
+		metrics:          newMetrics(),
+		policy:           RandomPolicy{},
+		minConfirmations: 1,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	// Wrapped in a DialHolder once, up front, so every config that reuses
+	// this Dialer shares the same comparable pointer. See WrapTransport.
+	d.holder = &transport.DialHolder{Dial: d.DialContext}
+	return d
+}
+
+// Close stops the endpoints resolver started by Start, if any, and
+// force-closes every connection the Dialer currently holds open, whether
+// to a discovered backend or to the original address. It gives callers
+// shutdown symmetry with the context passed to Start: once Close returns,
+// nothing is pinned to a backend anymore.
+func (d *Dialer) Close() {
+	d.mu.Lock()
+	cancel := d.cancel
+	d.backends = nil
+	d.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	d.connsMu.Lock()
+	targets := make([]string, 0, len(d.conns))
+	for b := range d.conns {
+		targets = append(targets, b)
+	}
+	d.connsMu.Unlock()
+	for _, b := range targets {
+		d.closeConnectionsTo(b)
+	}
+}
+
+// candidates returns the ordered list of addresses to try for a dial to
+// addr, deduplicated and with addr itself always included as the final
+// fallback.
+func (d *Dialer) candidates(addr string) []string {
+	d.mu.RLock()
+	backends := append([]string(nil), d.backends...)
+	d.mu.RUnlock()
+	backends = d.policy.Order(backends)
+
+	seen := make(map[string]bool, len(backends)+1)
+	var ordered []string
+	add := func(candidate string) {
+		if seen[candidate] {
+			return
+		}
+		seen[candidate] = true
+		ordered = append(ordered, candidate)
+	}
+
+	if d.bypass {
+		for _, b := range backends {
+			add(b)
+		}
+		add(addr)
+	} else {
+		add(addr)
+		for _, b := range backends {
+			add(b)
+		}
+	}
+	return ordered
+}
+
+// DialContext implements DialFunc. It tries the candidates for addr in
+// order, returning the first successful connection.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if atomic.LoadInt32(&d.draining) != 0 {
+		return nil, fmt.Errorf("multidialer: dialer is draining, refusing new dial to %s", addr)
+	}
+
+	candidates := d.candidates(addr)
+
+	var lastErr error
+	for i, candidate := range candidates {
+		conn, err := d.dialOne(ctx, network, candidate)
+		if err == nil {
+			if i > 0 {
+				d.recordFallthrough(addr, candidate)
+			}
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("multidialer: no candidates available for %s", addr)
+	}
+	return nil, lastErr
+}
+
+// notifyDialStarted, notifyDialFinished, and notifyConnClosed forward dial
+// and connection lifetime events to the configured Policy, if it tracks
+// stats. They are no-ops for policies that don't need feedback, like
+// RandomPolicy and RoundRobinPolicy.
+
+func (d *Dialer) notifyDialStarted(backend string) {
+	if t, ok := d.policy.(statsTracker); ok {
+		t.dialStarted(backend)
+	}
+}
+
+func (d *Dialer) notifyDialFinished(backend string, dur time.Duration, err error) {
+	if t, ok := d.policy.(statsTracker); ok {
+		t.dialFinished(backend, dur, err)
+	}
+}
+
+func (d *Dialer) notifyConnClosed(backend string) {
+	if t, ok := d.policy.(statsTracker); ok {
+		t.connClosed(backend)
+	}
+}
+
+func (d *Dialer) recordFallthrough(original, chosen string) {
+	if d.bypass {
+		if chosen == original {
+			d.metrics.bypassFallbacks.Inc()
+		}
+		return
+	}
+	d.metrics.failoverFallthroughs.Inc()
+}
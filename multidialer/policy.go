@@ -0,0 +1,253 @@
+package multidialer
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Policy orders a Dialer's discovered backends for a single dial attempt.
+// The Dialer still tries the ordered backends in turn and falls through to
+// the next on failure exactly as it always has; a Policy only changes
+// which backend goes first, replacing the resolver's implicit ordering
+// with a real load-balancing decision.
+type Policy interface {
+	// Order returns backends reordered according to the policy. It must
+	// not mutate backends.
+	Order(backends []string) []string
+}
+
+// statsTracker is implemented by policies that need feedback about dial
+// outcomes and connection lifetimes, such as in-flight counts or EWMA
+// latency. The Dialer calls these around every dial and on every
+// connection close if the configured Policy implements it.
+type statsTracker interface {
+	dialStarted(backend string)
+	dialFinished(backend string, d time.Duration, err error)
+	connClosed(backend string)
+}
+
+// Option configures a Dialer at construction time.
+type Option func(*Dialer)
+
+// WithPolicy selects the backend ordering Policy a Dialer uses for each
+// dial. The default, if unset, is RandomPolicy.
+func WithPolicy(p Policy) Option {
+	return func(d *Dialer) {
+		d.policy = p
+	}
+}
+
+// RandomPolicy shuffles the backend list on every dial. It is the default
+// Policy.
+type RandomPolicy struct{}
+
+// Order implements Policy.
+func (RandomPolicy) Order(backends []string) []string {
+	shuffled := append([]string(nil), backends...)
+	rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled
+}
+
+// RoundRobinPolicy cycles the starting backend forward by one on every
+// call to Order.
+type RoundRobinPolicy struct {
+	mu   sync.Mutex
+	next int
+}
+
+// Order implements Policy.
+func (p *RoundRobinPolicy) Order(backends []string) []string {
+	if len(backends) == 0 {
+		return backends
+	}
+	p.mu.Lock()
+	start := p.next % len(backends)
+	p.next++
+	p.mu.Unlock()
+
+	ordered := make([]string, len(backends))
+	for i := range backends {
+		ordered[i] = backends[(start+i)%len(backends)]
+	}
+	return ordered
+}
+
+// backendStats holds the per-backend telemetry the latency-aware policies
+// use to score candidates: the number of in-flight dials, the number of
+// connections currently open, an EWMA of successful dial latency, and an
+// EWMA of the recent failure rate.
+type backendStats struct {
+	mu        sync.Mutex
+	inFlight  int
+	openConns int
+	ewmaRTT   float64
+	failRate  float64
+}
+
+func (s *backendStats) recordStart() {
+	s.mu.Lock()
+	s.inFlight++
+	s.mu.Unlock()
+}
+
+// recordClose is called when a previously opened connection to the
+// backend closes. It is distinct from the dial-in-progress accounting in
+// recordResult: a dial that never produces a connection (it failed) is
+// never tracked, so it must not be reclaimed here.
+func (s *backendStats) recordClose() {
+	s.mu.Lock()
+	if s.openConns > 0 {
+		s.openConns--
+	}
+	s.mu.Unlock()
+}
+
+// recordResult is called once per dial attempt, success or failure, and
+// reclaims the in-flight slot recordStart reserved for it. A successful
+// dial additionally opens a connection that recordClose will later close.
+func (s *backendStats) recordResult(d time.Duration, err error, rttAlpha, failAlpha float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inFlight > 0 {
+		s.inFlight--
+	}
+	if err != nil {
+		s.failRate = failAlpha + (1-failAlpha)*s.failRate
+		return
+	}
+	s.openConns++
+	if s.ewmaRTT == 0 {
+		s.ewmaRTT = d.Seconds()
+	} else {
+		s.ewmaRTT = rttAlpha*d.Seconds() + (1-rttAlpha)*s.ewmaRTT
+	}
+	s.failRate = (1 - failAlpha) * s.failRate
+}
+
+// statsByBackend is embedded by the stats-aware policies to give each one
+// its own map of backendStats, created lazily per backend address.
+type statsByBackend struct {
+	mu    sync.Mutex
+	stats map[string]*backendStats
+}
+
+func (m *statsByBackend) forBackend(backend string) *backendStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stats == nil {
+		m.stats = make(map[string]*backendStats)
+	}
+	s, ok := m.stats[backend]
+	if !ok {
+		s = &backendStats{}
+		m.stats[backend] = s
+	}
+	return s
+}
+
+// PowerOfTwoChoicesPolicy picks two random backends per dial and orders
+// the one with the fewer in-flight dials/open connections and lower EWMA
+// latency first, falling through the rest in their original order.
+type PowerOfTwoChoicesPolicy struct {
+	statsByBackend
+}
+
+// NewPowerOfTwoChoicesPolicy creates a PowerOfTwoChoicesPolicy ready to use.
+func NewPowerOfTwoChoicesPolicy() *PowerOfTwoChoicesPolicy {
+	return &PowerOfTwoChoicesPolicy{}
+}
+
+func (p *PowerOfTwoChoicesPolicy) score(backend string) float64 {
+	s := p.forBackend(backend)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return float64(s.inFlight+s.openConns) + s.ewmaRTT
+}
+
+// Order implements Policy.
+func (p *PowerOfTwoChoicesPolicy) Order(backends []string) []string {
+	if len(backends) < 2 {
+		return RandomPolicy{}.Order(backends)
+	}
+
+	i := rand.Intn(len(backends))
+	j := rand.Intn(len(backends) - 1)
+	if j >= i {
+		j++
+	}
+	first, second := backends[i], backends[j]
+	if p.score(first) > p.score(second) {
+		first, second = second, first
+	}
+
+	ordered := make([]string, 0, len(backends))
+	ordered = append(ordered, first, second)
+	for k, backend := range backends {
+		if k == i || k == j {
+			continue
+		}
+		ordered = append(ordered, backend)
+	}
+	return ordered
+}
+
+func (p *PowerOfTwoChoicesPolicy) dialStarted(backend string) {
+	p.forBackend(backend).recordStart()
+}
+
+func (p *PowerOfTwoChoicesPolicy) dialFinished(backend string, d time.Duration, err error) {
+	p.forBackend(backend).recordResult(d, err, 0.2, 0.1)
+}
+
+func (p *PowerOfTwoChoicesPolicy) connClosed(backend string) {
+	p.forBackend(backend).recordClose()
+}
+
+// WeightedPolicy orders backends by a score combining EWMA dial latency
+// and recent failure rate, lowest score first.
+type WeightedPolicy struct {
+	statsByBackend
+}
+
+// NewWeightedPolicy creates a WeightedPolicy ready to use.
+func NewWeightedPolicy() *WeightedPolicy {
+	return &WeightedPolicy{}
+}
+
+// unprobedRTT stands in for ewmaRTT on a backend that has never completed
+// a successful dial. Without it, a backend that has only ever failed (or
+// was just discovered) scores 0 regardless of failRate and sorts first,
+// exactly backwards from the doc comment above.
+const unprobedRTT = 1.0
+
+func (p *WeightedPolicy) score(backend string) float64 {
+	s := p.forBackend(backend)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rtt := s.ewmaRTT
+	if rtt == 0 {
+		rtt = unprobedRTT
+	}
+	// A backend failing recently is penalized heavily even if its
+	// historical latency looks good, so it sinks to the back of the list.
+	return rtt * (1 + 5*s.failRate)
+}
+
+// Order implements Policy.
+func (p *WeightedPolicy) Order(backends []string) []string {
+	ordered := append([]string(nil), backends...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return p.score(ordered[i]) < p.score(ordered[j])
+	})
+	return ordered
+}
+
+func (p *WeightedPolicy) dialStarted(string) {}
+
+func (p *WeightedPolicy) dialFinished(backend string, d time.Duration, err error) {
+	p.forBackend(backend).recordResult(d, err, 0.2, 0.1)
+}
+
+func (p *WeightedPolicy) connClosed(string) {}
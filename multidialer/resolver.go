@@ -0,0 +1,135 @@
+package multidialer
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	kubernetesServiceName      = "kubernetes"
+	kubernetesServiceNamespace = metav1.NamespaceDefault
+)
+
+// WithMinConfirmations sets how many consecutive zero-endpoint refreshes
+// the resolver must observe in a row before the Dialer actually shrinks
+// its backend list to match. Resolver errors never count toward this
+// streak, since they carry no information about the real backend set;
+// only successful refreshes that report zero endpoints do. The default,
+// if unset, is 1, meaning the first empty refresh is trusted, matching
+// the resolver's original behavior.
+func WithMinConfirmations(n int) Option {
+	return func(d *Dialer) {
+		if n > 0 {
+			d.minConfirmations = n
+		}
+	}
+}
+
+// Start begins watching the "kubernetes" Endpoints object using cs and
+// keeps the Dialer's backend list up to date until ctx is done or Close is
+// called.
+func (d *Dialer) Start(ctx context.Context, cs kubernetes.Interface) {
+	ctx, cancel := context.WithCancel(ctx)
+	d.mu.Lock()
+	d.cancel = cancel
+	d.mu.Unlock()
+
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", kubernetesServiceName).String()
+			return cs.CoreV1().Endpoints(kubernetesServiceNamespace).List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", kubernetesServiceName).String()
+			return cs.CoreV1().Endpoints(kubernetesServiceNamespace).Watch(ctx, options)
+		},
+	}
+	informer := cache.NewSharedInformer(listWatch, &corev1.Endpoints{}, 0)
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { d.updateBackends(obj) },
+		UpdateFunc: func(_, obj interface{}) {
+			d.updateBackends(obj)
+		},
+		// The Endpoints object disappearing is treated the same as it
+		// reporting zero addresses: it goes through the same
+		// confirmation guard rather than collapsing the backend set
+		// on the spot.
+		DeleteFunc: func(obj interface{}) { d.observeBackends(nil) },
+	})
+	_ = informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		d.onResolverError(err)
+	})
+	go informer.Run(ctx.Done())
+}
+
+// updateBackends recomputes the backend list from an Endpoints object.
+func (d *Dialer) updateBackends(obj interface{}) {
+	ep, ok := obj.(*corev1.Endpoints)
+	if !ok {
+		return
+	}
+	var backends []string
+	for _, subset := range ep.Subsets {
+		for _, port := range subset.Ports {
+			for _, addr := range subset.Addresses {
+				backends = append(backends, net.JoinHostPort(addr.IP, strconv.Itoa(int(port.Port))))
+			}
+		}
+	}
+	d.observeBackends(backends)
+}
+
+// onResolverError records that a refresh failed outright (e.g. a
+// transient 5xx from the apiserver, or a watch disconnect). It never
+// touches the backend list: an error means we don't know the current
+// state, not that it's empty, so the last known good list keeps serving
+// traffic indefinitely, however long the failure lasts.
+func (d *Dialer) onResolverError(err error) {
+	d.metrics.resolverErrors.Inc()
+}
+
+// observeBackends applies a resolver refresh to the backend list. A
+// non-empty result is always applied immediately and resets the empty
+// streak. A result reporting zero backends is suppressed -- the previous
+// list keeps being served -- until it has been observed minConfirmations
+// times in a row, so a transient blip (a partial watch bookmark, a single
+// bad list) can't collapse traffic onto the load balancer or fail dials
+// outright; only a backend set that's repeatedly, genuinely empty (e.g. a
+// scaled-down apiserver Deployment) is allowed through.
+func (d *Dialer) observeBackends(backends []string) {
+	d.mu.Lock()
+	if len(backends) == 0 {
+		d.emptyStreak++
+		if d.emptyStreak < d.minConfirmations {
+			d.mu.Unlock()
+			d.metrics.resolverEmptyGuarded.Inc()
+			return
+		}
+	} else {
+		d.emptyStreak = 0
+	}
+	old := d.backends
+	d.backends = backends
+	d.mu.Unlock()
+
+	d.metrics.backendsKnown.Set(float64(len(backends)))
+
+	stillPresent := make(map[string]bool, len(backends))
+	for _, b := range backends {
+		stillPresent[b] = true
+	}
+	for _, b := range old {
+		if !stillPresent[b] {
+			d.closeConnectionsTo(b)
+		}
+	}
+}
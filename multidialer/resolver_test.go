@@ -0,0 +1,58 @@
+package multidialer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestObserveBackendsGuardsTransientEmpty(t *testing.T) {
+	d := NewDialer(nil, WithMinConfirmations(3))
+	d.observeBackends([]string{"10.0.0.1:6443", "10.0.0.2:6443"})
+	if got := len(d.backends); got != 2 {
+		t.Fatalf("want 2 backends after initial list, got %d", got)
+	}
+
+	// A single transient empty refresh must not collapse the backend set.
+	d.observeBackends(nil)
+	if got := len(d.backends); got != 2 {
+		t.Fatalf("transient empty refresh shrank backends, got %d want 2", got)
+	}
+
+	// Recovery resets the empty streak.
+	d.observeBackends([]string{"10.0.0.1:6443", "10.0.0.2:6443"})
+	if got := len(d.backends); got != 2 {
+		t.Fatalf("want 2 backends after recovery, got %d", got)
+	}
+	if d.emptyStreak != 0 {
+		t.Fatalf("want empty streak reset after a non-empty refresh, got %d", d.emptyStreak)
+	}
+}
+
+func TestObserveBackendsIgnoresResolverErrors(t *testing.T) {
+	d := NewDialer(nil, WithMinConfirmations(3))
+	d.observeBackends([]string{"10.0.0.1:6443"})
+
+	boom := errors.New("boom")
+	for i := 0; i < 10; i++ {
+		d.onResolverError(boom)
+	}
+	if got := len(d.backends); got != 1 {
+		t.Fatalf("prolonged resolver errors shrank backends, got %d want 1", got)
+	}
+}
+
+func TestObserveBackendsAppliesConfirmedScaleDown(t *testing.T) {
+	d := NewDialer(nil, WithMinConfirmations(3))
+	d.observeBackends([]string{"10.0.0.1:6443"})
+
+	d.observeBackends(nil)
+	d.observeBackends(nil)
+	if got := len(d.backends); got != 1 {
+		t.Fatalf("want backends unchanged before the confirmation threshold, got %d", got)
+	}
+
+	d.observeBackends(nil)
+	if got := len(d.backends); got != 0 {
+		t.Fatalf("want backends cleared after %d confirming empty refreshes, got %d", d.minConfirmations, got)
+	}
+}
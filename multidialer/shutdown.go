@@ -0,0 +1,34 @@
+package multidialer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Drain marks the Dialer as draining: DialContext immediately refuses any
+// new dial, while connections already open are left alone so in-flight
+// requests can finish. Drain waits up to grace for those connections to
+// close on their own; once grace elapses (or immediately, if grace is
+// zero or negative), every remaining connection is force-closed and the
+// resolver started by Start is stopped, exactly like Close.
+func (d *Dialer) Drain(grace time.Duration) {
+	atomic.StoreInt32(&d.draining, 1)
+
+	if grace > 0 {
+		deadline := time.Now().Add(grace)
+		for time.Now().Before(deadline) && d.openConnCount() > 0 {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	d.Close()
+}
+
+func (d *Dialer) openConnCount() int {
+	d.connsMu.Lock()
+	defer d.connsMu.Unlock()
+	n := 0
+	for _, set := range d.conns {
+		n += len(set)
+	}
+	return n
+}
@@ -0,0 +1,41 @@
+package multidialer
+
+import (
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// WrapTransport builds config's http.RoundTripper directly through
+// client-go's transport.New, passing the Dialer's DialHolder in place of
+// config.Dial.
+//
+// A bare func value set on config.Dial is never equal to another func
+// value, so client-go's tlsTransportCache treats every config with a
+// non-nil Dial as uncacheable and mints a brand new *http.Transport (and
+// TLS session) for it. transport.DialHolder exists precisely to fix this:
+// it is a pointer, which is comparable, so rest.Config copies that reuse
+// the same Dialer also reuse the same cached transport. This matters for
+// callers who build many clientsets off one base config, e.g. one
+// impersonated clientset per request.
+//
+// WrapTransport sets config.Transport and clears the fields client-go
+// forbids alongside a custom Transport (TLSClientConfig, Dial); call it
+// before kubernetes.NewForConfig instead of assigning config.Dial
+// directly.
+func (d *Dialer) WrapTransport(config *rest.Config) error {
+	transportConfig, err := config.TransportConfig()
+	if err != nil {
+		return err
+	}
+	transportConfig.DialHolder = d.holder
+
+	rt, err := transport.New(transportConfig)
+	if err != nil {
+		return err
+	}
+
+	config.Transport = rt
+	config.TLSClientConfig = rest.TLSClientConfig{}
+	config.Dial = nil
+	return nil
+}